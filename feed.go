@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	atomContentType = "application/atom+xml; charset=utf-8"
+	rssContentType  = "application/rss+xml; charset=utf-8"
+)
+
+// feedHandler returns an http.HandlerFunc that serves the cached posts as a
+// feed of the given content type. If tag is non-empty, only posts carrying
+// that tag are included.
+func feedHandler(cfg SiteConfig, tag, contentType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		postCacheMu.RLock()
+		posts := postCache
+		postCacheMu.RUnlock()
+
+		if tag != "" {
+			posts = postsWithTag(posts, tag)
+			if len(posts) == 0 {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		updated := feedUpdated(posts)
+		etag := fmt.Sprintf(`"%d"`, updated.Unix())
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if ifModSince, err := time.Parse(http.TimeFormat, r.Header.Get("If-Modified-Since")); err == nil {
+			if !updated.After(ifModSince.Add(time.Second)) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", updated.Format(http.TimeFormat))
+		w.Header().Set("Content-Type", contentType)
+
+		var body string
+		if contentType == rssContentType {
+			body = renderRSS(cfg, posts, tag)
+		} else {
+			body = renderAtom(cfg, posts, tag)
+		}
+		w.Write([]byte(body))
+	}
+}
+
+func postsWithTag(posts []Post, tag string) []Post {
+	var out []Post
+	for _, p := range posts {
+		for _, t := range p.Tags {
+			if t == tag {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// feedUpdated returns the date of the newest post, or the zero time if
+// there are no posts.
+func feedUpdated(posts []Post) time.Time {
+	var newest time.Time
+	for _, p := range posts {
+		if p.Date.After(newest) {
+			newest = p.Date
+		}
+	}
+	return newest
+}
+
+// postTagURI builds a tag: URI (RFC 4151) identifying a post, suitable for
+// use as an Atom <id>.
+func postTagURI(cfg SiteConfig, p Post) string {
+	domain := strings.TrimPrefix(strings.TrimPrefix(cfg.BaseURL, "https://"), "http://")
+	return fmt.Sprintf("tag:%s,%s:%s", domain, p.Date.Format("2006-01-02"), p.Slug)
+}
+
+func feedTitle(cfg SiteConfig, tag string) string {
+	if tag == "" {
+		return cfg.Title
+	}
+	return fmt.Sprintf("%s — posts tagged “%s”", cfg.Title, tag)
+}
+
+func renderAtom(cfg SiteConfig, posts []Post, tag string) string {
+	var b strings.Builder
+
+	feedURL := cfg.BaseURL + "/feed.atom"
+	if tag != "" {
+		feedURL = cfg.BaseURL + "/tags/" + tag + "/feed.atom"
+	}
+
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	b.WriteString(`<feed xmlns="http://www.w3.org/2005/Atom">` + "\n")
+	fmt.Fprintf(&b, "  <title>%s</title>\n", escapeXML(feedTitle(cfg, tag)))
+	fmt.Fprintf(&b, "  <id>%s</id>\n", escapeXML(feedURL))
+	fmt.Fprintf(&b, "  <link rel=\"self\" href=\"%s\"/>\n", escapeXML(feedURL))
+	fmt.Fprintf(&b, "  <link href=\"%s\"/>\n", escapeXML(cfg.BaseURL))
+	fmt.Fprintf(&b, "  <updated>%s</updated>\n", feedUpdated(posts).Format(time.RFC3339))
+	b.WriteString("  <author>\n")
+	fmt.Fprintf(&b, "    <name>%s</name>\n", escapeXML(cfg.Author))
+	fmt.Fprintf(&b, "    <uri>%s</uri>\n", escapeXML(cfg.AuthorURL))
+	b.WriteString("  </author>\n")
+
+	for _, p := range posts {
+		b.WriteString("  <entry>\n")
+		fmt.Fprintf(&b, "    <title>%s</title>\n", escapeXML(p.Title))
+		fmt.Fprintf(&b, "    <id>%s</id>\n", escapeXML(postTagURI(cfg, p)))
+		fmt.Fprintf(&b, "    <link href=\"%s\"/>\n", escapeXML(cfg.BaseURL+"/post/"+p.Slug))
+		fmt.Fprintf(&b, "    <updated>%s</updated>\n", p.Date.Format(time.RFC3339))
+		fmt.Fprintf(&b, "    <content type=\"html\">%s</content>\n", escapeXML(string(p.Content)))
+		b.WriteString("  </entry>\n")
+	}
+
+	b.WriteString("</feed>\n")
+	return b.String()
+}
+
+func renderRSS(cfg SiteConfig, posts []Post, tag string) string {
+	var b strings.Builder
+
+	feedURL := cfg.BaseURL + "/feed.xml"
+
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	b.WriteString(`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">` + "\n")
+	b.WriteString("  <channel>\n")
+	fmt.Fprintf(&b, "    <title>%s</title>\n", escapeXML(feedTitle(cfg, tag)))
+	fmt.Fprintf(&b, "    <link>%s</link>\n", escapeXML(cfg.BaseURL))
+	fmt.Fprintf(&b, "    <atom:link href=\"%s\" rel=\"self\" type=\"application/rss+xml\"/>\n", escapeXML(feedURL))
+	fmt.Fprintf(&b, "    <lastBuildDate>%s</lastBuildDate>\n", feedUpdated(posts).Format(time.RFC1123Z))
+
+	for _, p := range posts {
+		b.WriteString("    <item>\n")
+		fmt.Fprintf(&b, "      <title>%s</title>\n", escapeXML(p.Title))
+		fmt.Fprintf(&b, "      <link>%s</link>\n", escapeXML(cfg.BaseURL+"/post/"+p.Slug))
+		fmt.Fprintf(&b, "      <guid isPermaLink=\"false\">%s</guid>\n", escapeXML(postTagURI(cfg, p)))
+		fmt.Fprintf(&b, "      <pubDate>%s</pubDate>\n", p.Date.Format(time.RFC1123Z))
+		fmt.Fprintf(&b, "      <description>%s</description>\n", escapeXML(string(p.Content)))
+		b.WriteString("    </item>\n")
+	}
+
+	b.WriteString("  </channel>\n")
+	b.WriteString("</rss>\n")
+	return b.String()
+}
+
+func escapeXML(s string) string {
+	r := strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		`"`, "&quot;",
+	)
+	return r.Replace(s)
+}
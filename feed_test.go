@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderAtomEscapesTagInLink(t *testing.T) {
+	cfg := SiteConfig{Title: "Test Blog", BaseURL: "https://example.com", Author: "tester"}
+	posts := []Post{{Title: "Hello", Slug: "hello", Date: time.Now()}}
+
+	out := renderAtom(cfg, posts, `a&b`)
+
+	if strings.Contains(out, `href="https://example.com/tags/a&b/feed.atom"`) {
+		t.Errorf("unescaped & in feed link attribute: %s", out)
+	}
+	if !strings.Contains(out, `href="https://example.com/tags/a&amp;b/feed.atom"`) {
+		t.Errorf("expected escaped &amp; in feed link attribute, got: %s", out)
+	}
+}
+
+func TestPostsWithTagMatchesExactTag(t *testing.T) {
+	posts := []Post{
+		{Slug: "a", Tags: []string{"go"}},
+		{Slug: "b", Tags: []string{"rust"}},
+	}
+
+	got := postsWithTag(posts, "go")
+	if len(got) != 1 || got[0].Slug != "a" {
+		t.Errorf("postsWithTag(posts, \"go\") = %v; want [a]", got)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/adrg/frontmatter"
+	"github.com/araddon/dateparse"
+)
+
+// PostMatter is the typed shape of a post's YAML frontmatter block.
+type PostMatter struct {
+	Title       string    `yaml:"title"`
+	Date        time.Time `yaml:"date"`
+	Description string    `yaml:"description"`
+	Tags        []string  `yaml:"tags"`
+	Draft       bool      `yaml:"draft"`
+	Slug        string    `yaml:"slug"`
+}
+
+// rawMatter mirrors PostMatter but keeps Date as a string, so we can fall
+// back to dateparse when it isn't strict RFC3339 (which is all
+// encoding.TextUnmarshaler-backed time.Time accepts).
+type rawMatter struct {
+	Title       string   `yaml:"title"`
+	Date        string   `yaml:"date"`
+	Description string   `yaml:"description"`
+	Tags        []string `yaml:"tags"`
+	Draft       bool     `yaml:"draft"`
+	Slug        string   `yaml:"slug"`
+}
+
+// parseFrontMatter extracts the YAML frontmatter block from content and
+// returns the typed matter along with the remaining Markdown body. It
+// tolerates dates that aren't strict RFC3339 by retrying with
+// github.com/araddon/dateparse.
+func parseFrontMatter(content []byte) (PostMatter, []byte, error) {
+	var matter PostMatter
+	body, err := frontmatter.Parse(strings.NewReader(string(content)), &matter)
+	if err == nil {
+		normalizeTags(&matter)
+		return matter, body, nil
+	}
+
+	// The strict time.Time field likely failed to unmarshal; retry with a
+	// string Date and parse it leniently.
+	var raw rawMatter
+	body, rawErr := frontmatter.Parse(strings.NewReader(string(content)), &raw)
+	if rawErr != nil {
+		return PostMatter{}, nil, err
+	}
+
+	date, dateErr := dateparse.ParseAny(raw.Date)
+	if dateErr != nil {
+		return PostMatter{}, nil, err
+	}
+
+	matter = PostMatter{
+		Title:       raw.Title,
+		Date:        date,
+		Description: raw.Description,
+		Tags:        raw.Tags,
+		Draft:       raw.Draft,
+		Slug:        raw.Slug,
+	}
+	normalizeTags(&matter)
+	return matter, body, nil
+}
+
+// normalizeTags lower-cases and trims tags in place, matching the
+// lower-cased contract documented on Post.Tags (and required for tag URLs
+// like /tags/go/feed.atom to match posts tagged "Go").
+func normalizeTags(matter *PostMatter) {
+	for i, tag := range matter.Tags {
+		matter.Tags[i] = strings.ToLower(strings.TrimSpace(tag))
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFrontMatterNormalizesTags(t *testing.T) {
+	content := []byte("---\ntitle: Hello\ndate: 2024-01-02\ntags: [Go, \" Testing \"]\n---\nbody\n")
+
+	matter, _, err := parseFrontMatter(content)
+	if err != nil {
+		t.Fatalf("parseFrontMatter: %v", err)
+	}
+
+	want := []string{"go", "testing"}
+	if len(matter.Tags) != len(want) {
+		t.Fatalf("Tags = %v; want %v", matter.Tags, want)
+	}
+	for i, tag := range want {
+		if matter.Tags[i] != tag {
+			t.Errorf("Tags[%d] = %q; want %q", i, matter.Tags[i], tag)
+		}
+	}
+}
+
+func TestParseFrontMatterDateFallback(t *testing.T) {
+	content := []byte("---\ntitle: Hello\ndate: Jan 2, 2024\n---\nbody\n")
+
+	matter, _, err := parseFrontMatter(content)
+	if err != nil {
+		t.Fatalf("parseFrontMatter: %v", err)
+	}
+
+	want := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	if !matter.Date.Equal(want) {
+		t.Errorf("Date = %v; want %v", matter.Date, want)
+	}
+}
@@ -1,84 +1,117 @@
 package main
 
 import (
-	"bytes"
 	"embed"
+	"flag"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
-
-	"github.com/yuin/goldmark"
+	"unicode/utf8"
 )
 
+// showDrafts, when set via -drafts, keeps Draft: true posts in postCache.
+var showDrafts = flag.Bool("drafts", false, "include draft posts in the cache")
+
 //go:embed content/*.md
 var contentFS embed.FS
 
 //go:embed templates/*.html
 var templatesFS embed.FS
 
+// SiteConfig holds the site-wide settings that used to be hard-coded
+// strings scattered across handlers (title, base URL, author, ...).
+type SiteConfig struct {
+	Title     string
+	BaseURL   string // e.g. "https://renvins.example" (no trailing slash)
+	Author    string
+	AuthorURL string
+}
+
+// defaultSiteConfig is used until real configuration loading exists.
+var defaultSiteConfig = SiteConfig{
+	Title:     "renvins' thoughts blog",
+	BaseURL:   "https://renvins.example",
+	Author:    "renvins",
+	AuthorURL: "https://renvins.example",
+}
+
 func main() {
+	flag.Parse()
+
 	// Load posts into RAM
 	loadPosts()
 
+	cfg := defaultSiteConfig
+
+	renderer, err := NewRenderer(templatesFS, "templates/layout.html", "templates/index.html", "templates/post.html")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+
 	// Route: homepage
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			http.NotFound(w, r)
-			return
-		}
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		postCacheMu.RLock()
+		posts := postCache
+		postCacheMu.RUnlock()
+
+		renderer.Render(w, "index", IndexData{
+			Title: cfg.Title,
+			Posts: posts,
+		})
+	})
 
-		tmpl, err := template.ParseFS(templatesFS, "templates/layout.html", "templates/index.html")
-		if err != nil {
-			http.Error(w, "Template Error: "+err.Error(), 500)
-			return
-		}
+	// Route: Chroma syntax-highlighting stylesheet
+	mux.HandleFunc("/assets/chroma.css", chromaCSSHandler)
 
-		data := IndexData{
-			Title: "renvins' thoughts blog",
-			Posts: postCache,
-		}
+	// Route: force a full content rescan
+	mux.HandleFunc("/admin/reload", adminReloadHandler)
+
+	// Route: Micropub (h-entry creation)
+	mux.HandleFunc("/micropub", micropubHandler(cfg))
 
-		tmpl.Execute(w, data)
+	// Route: Atom/RSS feeds
+	mux.HandleFunc("/feed.atom", feedHandler(cfg, "", atomContentType))
+	mux.HandleFunc("/feed.xml", feedHandler(cfg, "", rssContentType))
+	mux.HandleFunc("/tags/", func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/tags/")
+		tag := strings.TrimSuffix(rest, "/feed.atom")
+		if tag == rest {
+			http.NotFound(w, r)
+			return
+		}
+		feedHandler(cfg, tag, atomContentType)(w, r)
 	})
 
 	// Route: single post
-	http.HandleFunc("/post/", func(w http.ResponseWriter, r *http.Request) {
-		// Extract slug
-		slug := strings.TrimPrefix(r.URL.Path, "/post/")
-
-		var foundPost Post
-		found := false
-		for _, p := range postCache {
-			if p.Slug == slug {
-				foundPost = p
-				found = true
-				break
-			}
-		}
+	mux.HandleFunc("GET /post/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		slug := r.PathValue("slug")
+
+		postCacheMu.RLock()
+		foundPost, found := postLookup[slug]
+		postCacheMu.RUnlock()
 
 		if !found {
 			http.NotFound(w, r)
 			return
 		}
 
-		// Parse layout + post
-		tmpl := template.Must(template.ParseFiles("templates/layout.html", "templates/post.html"))
-
-		data := PostData{
+		renderer.Render(w, "post", PostData{
 			Title: foundPost.Title,
 			Post:  foundPost,
-		}
-
-		tmpl.Execute(w, data)
+		})
 	})
 
 	fmt.Println("Listening on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	log.Fatal(http.ListenAndServe(":8080", mux))
 }
 
 // Post represents a single blog entry
@@ -88,79 +121,168 @@ type Post struct {
 	Slug    string        // The URL path
 	Content template.HTML // Pre-rendered HTML content
 	Summary string        // For the homepage preview
+	Tags    []string      // Tag names, lower-cased
+	TOC     template.HTML // Rendered heading tree, for the post sidebar
 }
 
-// Global cache to store posts in RAM
-var postCache []Post
+// postCache is the in-RAM list of posts served by every handler, and
+// postLookup is the same posts keyed by slug for O(1) resolution. pathToSlug
+// tracks, for -content-dir setups, which slug each source file currently
+// resolves to -- frontmatter can set Slug to something other than the
+// filename, so watch.go's fsnotify reloader needs this to remove the right
+// entry on delete. postCacheMu guards all three (and the incremental
+// updates made by the fsnotify-driven reloader in watch.go) against
+// concurrent HTTP reads.
+var (
+	postCache   []Post
+	postLookup  map[string]Post
+	pathToSlug  map[string]string
+	postCacheMu sync.RWMutex
+)
 
+// loadPosts populates postCache at startup and, if -content-dir points at
+// a real directory, starts watching it for changes.
 func loadPosts() {
-	files, err := contentFS.ReadDir("content")
+	rescanPosts()
+
+	if dir := *contentDirFlag; dir != "" {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			go watchContent(dir)
+		}
+	}
+}
+
+// rescanPosts does a full reload of postCache from whichever source is
+// configured, without touching the fsnotify watcher. It backs loadPosts
+// at startup and the /admin/reload endpoint.
+func rescanPosts() {
+	dir := *contentDirFlag
+
+	var (
+		posts []Post
+		paths map[string]string
+		err   error
+	)
+
+	if dir != "" {
+		if info, statErr := os.Stat(dir); statErr == nil && info.IsDir() {
+			posts, paths, err = loadPostsFromDir(dir)
+		} else {
+			log.Printf("content dir %q not found, falling back to embedded content\n", dir)
+			posts, err = loadPostsFromEmbed()
+		}
+	} else {
+		posts, err = loadPostsFromEmbed()
+	}
+
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	var posts []Post
+	postCacheMu.Lock()
+	pathToSlug = paths
+	postCacheMu.Unlock()
+
+	setPostCache(posts)
+}
+
+// loadPostsFromEmbed reads every *.md file baked into contentFS.
+func loadPostsFromEmbed() ([]Post, error) {
+	files, err := contentFS.ReadDir("content")
+	if err != nil {
+		return nil, err
+	}
 
+	var posts []Post
 	for _, fileEntry := range files {
-		// Read the raw file
 		content, err := contentFS.ReadFile("content/" + fileEntry.Name())
 		if err != nil {
 			log.Println("Error reading file:", err)
 			continue
 		}
 
-		// Parse the frontmatter (metadata)
-		// We assume the file starts with "---", metadata, "---"
-		parts := strings.SplitN(string(content), "---", 3)
-		if len(parts) < 3 {
-			log.Println("Error parsing file:", fileEntry.Name())
+		slug := strings.TrimSuffix(fileEntry.Name(), filepath.Ext(fileEntry.Name()))
+		post, err := parsePostFile(slug, content)
+		if err != nil {
+			log.Println("Error parsing frontmatter:", fileEntry.Name(), err)
 			continue
 		}
-
-		metaRaw := parts[1]
-		bodyRaw := parts[2]
-
-		// Extract metadata manually
-		post := Post{}
-		lines := strings.Split(metaRaw, "\n")
-		for _, line := range lines {
-			if strings.HasPrefix(line, "Title: ") {
-				post.Title = strings.TrimPrefix(line, "Title: ")
-			}
-			if strings.HasPrefix(line, "Date: ") {
-				dateStr := strings.TrimPrefix(line, "Date: ")
-				post.Date, _ = time.Parse("2006-01-02", strings.TrimSpace(dateStr))
-			}
+		if post != nil {
+			posts = append(posts, *post)
 		}
+	}
+	return posts, nil
+}
+
+// parsePostFile turns the raw contents of a single Markdown file into a
+// Post. filenameSlug is used unless the frontmatter sets an explicit Slug.
+// It returns (nil, nil) for drafts when -drafts wasn't passed.
+func parsePostFile(filenameSlug string, content []byte) (*Post, error) {
+	matter, bodyRaw, err := parseFrontMatter(content)
+	if err != nil {
+		return nil, err
+	}
 
-		// Generate the slug from the filename
-		filename := fileEntry.Name()
-		post.Slug = strings.TrimSuffix(filename, filepath.Ext(filename))
+	if matter.Draft && !*showDrafts {
+		return nil, nil
+	}
 
-		// Convert markdown body to HTML using Goldmark
-		var buf bytes.Buffer
-		if err := goldmark.Convert([]byte(bodyRaw), &buf); err != nil {
-			log.Println("Error converting goldmark:", err)
-			continue
-		}
-		post.Content = template.HTML(buf.String())
+	post := Post{
+		Title: matter.Title,
+		Date:  matter.Date,
+		Tags:  matter.Tags,
+		Slug:  filenameSlug,
+	}
+	if matter.Slug != "" {
+		post.Slug = matter.Slug
+	}
 
-		if len(bodyRaw) > 150 {
-			post.Summary = bodyRaw[:150] + "..."
-		} else {
-			post.Summary = bodyRaw
-		}
+	// Convert markdown body to HTML using the shared Goldmark pipeline.
+	content2, toc, err := renderMarkdown(bodyRaw)
+	if err != nil {
+		return nil, err
+	}
+	post.Content = content2
+	post.TOC = toc
 
-		posts = append(posts, post)
+	if matter.Description != "" {
+		post.Summary = matter.Description
+	} else {
+		post.Summary = truncateRunes(strings.TrimSpace(string(bodyRaw)), 150)
 	}
 
-	// Sort by date
+	return &post, nil
+}
+
+// setPostCache sorts posts by date (newest first) and installs them, along
+// with a freshly built slug index, as the live cache.
+func setPostCache(posts []Post) {
 	sort.Slice(posts, func(i, j int) bool {
 		return posts[i].Date.After(posts[j].Date)
 	})
 
+	lookup := make(map[string]Post, len(posts))
+	for _, p := range posts {
+		lookup[p.Slug] = p
+	}
+
+	postCacheMu.Lock()
 	postCache = posts
-	fmt.Println("Loaded posts:", len(postCache))
+	postLookup = lookup
+	postCacheMu.Unlock()
+
+	fmt.Println("Loaded posts:", len(posts))
+}
+
+// truncateRunes trims s to at most n runes, appending "..." if it was cut.
+// Operating on runes (rather than bytes) avoids splitting multi-byte UTF-8
+// sequences in the middle.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n]) + "..."
 }
 
 // Data passed to the index template
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	mathjax "github.com/litao91/goldmark-mathjax"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark-emoji"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// chromaStyleName picks the Chroma syntax-highlighting theme. Paired with
+// the /assets/chroma.css handler below, which must use the same style.
+const chromaStyleName = "github"
+
+// md is the single, fully-configured Markdown pipeline used for every post
+// and for Micropub-submitted content. It is built once at startup so the
+// (relatively expensive) extension setup only happens once.
+var md = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		extension.Footnote,
+		extension.Typographer,
+		emoji.Emoji,
+		mathjax.MathJax,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle(chromaStyleName),
+			highlighting.WithFormatOptions(
+				chromahtml.WithClasses(true),
+			),
+		),
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+	),
+)
+
+// renderMarkdown converts Markdown source to sanitized-by-convention HTML
+// (the surrounding templates already trust post content) and a rendered
+// table of contents built from the resulting heading tree.
+func renderMarkdown(source []byte) (content template.HTML, toc template.HTML, err error) {
+	doc := md.Parser().Parse(text.NewReader(source))
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, doc); err != nil {
+		return "", "", err
+	}
+
+	return template.HTML(buf.String()), buildTOC(doc, source), nil
+}
+
+// tocHeading is one entry in the rendered table of contents.
+type tocHeading struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+// buildTOC walks the parsed AST collecting headings (in document order) and
+// renders them as a nested <ul> sidebar, indented by heading level.
+func buildTOC(doc ast.Node, source []byte) template.HTML {
+	var headings []tocHeading
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		h, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		var id string
+		if v, ok := h.AttributeString("id"); ok {
+			if b, ok := v.([]byte); ok {
+				id = string(b)
+			}
+		}
+
+		headings = append(headings, tocHeading{
+			Level: h.Level,
+			ID:    id,
+			Text:  string(h.Text(source)),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+
+	if len(headings) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(`<ul class="toc">`)
+	for _, h := range headings {
+		fmt.Fprintf(&b, `<li class="toc-level-%d"><a href="#%s">%s</a></li>`, h.Level, h.ID, template.HTMLEscapeString(h.Text))
+	}
+	b.WriteString(`</ul>`)
+
+	return template.HTML(b.String())
+}
+
+// chromaCSSHandler serves the generated Chroma stylesheet for the
+// configured style, matching the CSS classes goldmark-highlighting emits
+// (since it's set up with chromahtml.WithClasses(true)).
+func chromaCSSHandler(w http.ResponseWriter, r *http.Request) {
+	style := styles.Get(chromaStyleName)
+	if style == nil {
+		style = styles.Fallback
+	}
+
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	if err := formatter.WriteCSS(w, style); err != nil {
+		http.Error(w, "CSS Error: "+err.Error(), 500)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownTOCHeadingIDs(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+		want   []string // expected #id anchors, in order
+	}{
+		{
+			name:   "single heading",
+			source: "# Hello World\n\nSome text.\n",
+			want:   []string{"#hello-world"},
+		},
+		{
+			name:   "multiple headings",
+			source: "# Intro\n\nBody.\n\n## Details\n\nMore.\n",
+			want:   []string{"#intro", "#details"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, toc, err := renderMarkdown([]byte(tc.source))
+			if err != nil {
+				t.Fatalf("renderMarkdown: %v", err)
+			}
+
+			for _, anchor := range tc.want {
+				if !strings.Contains(string(toc), `href="`+anchor+`"`) {
+					t.Errorf("toc = %s; want anchor %q", toc, anchor)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"gopkg.in/yaml.v2"
+)
+
+// TokenVerifier authenticates an inbound Micropub request. The default
+// implementation checks a single static bearer token from an environment
+// variable; swapping in an IndieAuth-backed verifier later only means
+// implementing this interface.
+type TokenVerifier interface {
+	Verify(r *http.Request) bool
+}
+
+// staticTokenVerifier accepts one shared bearer token, via the
+// Authorization header or (per the Micropub spec's form-encoded fallback)
+// an access_token form field.
+type staticTokenVerifier struct {
+	token string
+}
+
+func (v staticTokenVerifier) Verify(r *http.Request) bool {
+	if v.token == "" {
+		return false
+	}
+	if r.Header.Get("Authorization") == "Bearer "+v.token {
+		return true
+	}
+	return r.FormValue("access_token") == v.token
+}
+
+// micropubVerifier is the TokenVerifier used by micropubHandler.
+var micropubVerifier TokenVerifier = staticTokenVerifier{token: os.Getenv("MICROPUB_TOKEN")}
+
+// micropubHandler implements the subset of the W3C Micropub spec needed to
+// create h-entry posts from external clients: POST to create, and the
+// ?q=config / ?q=source / ?q=category GET queries.
+func micropubHandler(cfg SiteConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !micropubVerifier.Verify(r) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			micropubQuery(cfg, w, r)
+		case http.MethodPost:
+			micropubCreate(cfg, w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func micropubQuery(cfg SiteConfig, w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("q") {
+	case "config":
+		writeJSON(w, map[string]any{})
+	case "source":
+		post, ok := findPostByURL(cfg, r.URL.Query().Get("url"))
+		if !ok {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, micropubSourceFor(post))
+	case "category":
+		writeJSON(w, map[string]any{"categories": allTags()})
+	default:
+		http.Error(w, "Bad Request: unsupported q", http.StatusBadRequest)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+// micropubEntry is the parsed, transport-agnostic form of an incoming
+// h-entry creation request.
+type micropubEntry struct {
+	Name       string
+	Content    string
+	Categories []string
+	Published  time.Time
+	Slug       string
+}
+
+func micropubCreate(cfg SiteConfig, w http.ResponseWriter, r *http.Request) {
+	entry, err := parseMicropubEntry(r)
+	if err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if entry.Name == "" || entry.Content == "" {
+		http.Error(w, "Bad Request: name and content are required", http.StatusBadRequest)
+		return
+	}
+
+	// Always run the slug through slugify, including the client-supplied
+	// mp-slug -- otherwise a value like "../../../tmp/evil" reaches
+	// writePostFile unsanitized and escapes the content directory.
+	slug := slugify(entry.Slug)
+	if slug == "" {
+		slug = slugify(entry.Name)
+	}
+	if slug == "" {
+		http.Error(w, "Bad Request: could not derive a slug from name or mp-slug", http.StatusBadRequest)
+		return
+	}
+
+	published := entry.Published
+	if published.IsZero() {
+		published = time.Now()
+	}
+
+	path, err := writePostFile(slug, entry.Name, published, entry.Categories, entry.Content)
+	if err != nil {
+		http.Error(w, "Could not save post: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	post, err := loadPostFromPath(path)
+	if err != nil || post == nil {
+		http.Error(w, "Post saved but could not be re-read", http.StatusInternalServerError)
+		return
+	}
+	upsertPost(*post)
+
+	w.Header().Set("Location", cfg.BaseURL+"/post/"+post.Slug)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func parseMicropubEntry(r *http.Request) (micropubEntry, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return parseMicropubJSON(r)
+	}
+	return parseMicropubForm(r)
+}
+
+func parseMicropubForm(r *http.Request) (micropubEntry, error) {
+	if err := r.ParseForm(); err != nil {
+		return micropubEntry{}, err
+	}
+
+	entry := micropubEntry{
+		Name:       r.FormValue("name"),
+		Content:    r.FormValue("content"),
+		Slug:       r.FormValue("mp-slug"),
+		Categories: r.Form["category[]"],
+	}
+	if len(entry.Categories) == 0 {
+		entry.Categories = r.Form["category"]
+	}
+	if pub := r.FormValue("published"); pub != "" {
+		entry.Published, _ = dateparse.ParseAny(pub)
+	}
+	return entry, nil
+}
+
+func parseMicropubJSON(r *http.Request) (micropubEntry, error) {
+	var body struct {
+		Type       []string            `json:"type"`
+		Properties map[string][]string `json:"properties"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return micropubEntry{}, err
+	}
+
+	first := func(key string) string {
+		if vs := body.Properties[key]; len(vs) > 0 {
+			return vs[0]
+		}
+		return ""
+	}
+
+	entry := micropubEntry{
+		Name:       first("name"),
+		Content:    first("content"),
+		Slug:       first("mp-slug"),
+		Categories: body.Properties["category"],
+	}
+	if pub := first("published"); pub != "" {
+		entry.Published, _ = dateparse.ParseAny(pub)
+	}
+	return entry, nil
+}
+
+// writePostFile renders frontmatter + content to a new Markdown file in
+// -content-dir and returns its path. Micropub has nowhere to write to
+// without a live content directory configured.
+func writePostFile(slug, title string, date time.Time, tags []string, content string) (string, error) {
+	dir := *contentDirFlag
+	if dir == "" {
+		return "", fmt.Errorf("no -content-dir configured; Micropub needs a writable content directory")
+	}
+
+	matter := PostMatter{
+		Title: title,
+		Date:  date,
+		Tags:  tags,
+	}
+	matterYAML, err := yaml.Marshal(matter)
+	if err != nil {
+		return "", err
+	}
+
+	var fm strings.Builder
+	fm.WriteString("---\n")
+	fm.Write(matterYAML)
+	fm.WriteString("---\n\n")
+	fm.WriteString(content)
+	fm.WriteString("\n")
+
+	path := filepath.Join(dir, slug+".md")
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("a post with slug %q already exists", slug)
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, []byte(fm.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func findPostByURL(cfg SiteConfig, rawURL string) (Post, bool) {
+	prefix := cfg.BaseURL + "/post/"
+	if !strings.HasPrefix(rawURL, prefix) {
+		return Post{}, false
+	}
+	slug := strings.TrimPrefix(rawURL, prefix)
+
+	postCacheMu.RLock()
+	defer postCacheMu.RUnlock()
+	for _, p := range postCache {
+		if p.Slug == slug {
+			return p, true
+		}
+	}
+	return Post{}, false
+}
+
+func allTags() []string {
+	postCacheMu.RLock()
+	defer postCacheMu.RUnlock()
+
+	seen := make(map[string]bool)
+	var tags []string
+	for _, p := range postCache {
+		for _, t := range p.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func micropubSourceFor(post Post) map[string]any {
+	return map[string]any{
+		"type": []string{"h-entry"},
+		"properties": map[string]any{
+			"name":      []string{post.Title},
+			"content":   []string{string(post.Content)},
+			"category":  post.Tags,
+			"published": []string{post.Date.Format(time.RFC3339)},
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWritePostFileRejectsPathTraversalSlug(t *testing.T) {
+	dir := t.TempDir()
+	outsideDir := t.TempDir()
+
+	old := *contentDirFlag
+	*contentDirFlag = dir
+	defer func() { *contentDirFlag = old }()
+
+	// Simulates what micropubCreate now does: slugify the client-supplied
+	// mp-slug before it ever reaches writePostFile.
+	slug := slugify("../../../../" + outsideDir + "/evil")
+
+	path, err := writePostFile(slug, "Evil", time.Now(), nil, "body")
+	if err != nil {
+		t.Fatalf("writePostFile: %v", err)
+	}
+
+	if filepath.Dir(path) != dir {
+		t.Errorf("post written outside content dir: %s", path)
+	}
+	if _, err := os.Stat(filepath.Join(outsideDir, "evil.md")); err == nil {
+		t.Errorf("file escaped into %s", outsideDir)
+	}
+}
+
+func TestWritePostFileRejectsCollision(t *testing.T) {
+	dir := t.TempDir()
+
+	old := *contentDirFlag
+	*contentDirFlag = dir
+	defer func() { *contentDirFlag = old }()
+
+	if _, err := writePostFile("dup", "First", time.Now(), nil, "body"); err != nil {
+		t.Fatalf("first writePostFile: %v", err)
+	}
+
+	if _, err := writePostFile("dup", "Second", time.Now(), nil, "body"); err == nil {
+		t.Error("expected an error writing a colliding slug, got nil")
+	}
+}
+
+func TestWritePostFileRoundTripsSpecialCharacters(t *testing.T) {
+	dir := t.TempDir()
+
+	old := *contentDirFlag
+	*contentDirFlag = dir
+	defer func() { *contentDirFlag = old }()
+
+	title := `My "Quote" and \ backslash`
+	tags := []string{"go", "tag, with comma"}
+
+	path, err := writePostFile("special", title, time.Now(), tags, "body")
+	if err != nil {
+		t.Fatalf("writePostFile: %v", err)
+	}
+
+	post, err := loadPostFromPath(path)
+	if err != nil {
+		t.Fatalf("loadPostFromPath: %v", err)
+	}
+	if post.Title != title {
+		t.Errorf("Title = %q; want %q", post.Title, title)
+	}
+	if len(post.Tags) != 2 || post.Tags[0] != "go" || post.Tags[1] != "tag, with comma" {
+		t.Errorf("Tags = %v; want [go, \"tag, with comma\"]", post.Tags)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// templateFuncs are available to every template parsed by NewRenderer.
+var templateFuncs = template.FuncMap{
+	"formatDate":    formatDate,
+	"safeHTML":      safeHTML,
+	"slugify":       slugify,
+	"truncateWords": truncateWords,
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("January 2, 2006")
+}
+
+func safeHTML(s string) template.HTML {
+	return template.HTML(s)
+}
+
+func truncateWords(s string, n int) string {
+	words := strings.Fields(s)
+	if len(words) <= n {
+		return s
+	}
+	return strings.Join(words[:n], " ") + "..."
+}
+
+// Renderer parses every page template once at startup from an fs.FS (the
+// embedded templatesFS in practice) and serves cached *template.Template
+// instances by page name, instead of re-parsing on every request.
+type Renderer struct {
+	pages map[string]*template.Template
+}
+
+// NewRenderer parses layout alongside each of pages, keyed by the page
+// file's base name without extension (e.g. "templates/post.html" -> "post").
+func NewRenderer(templateFS fs.FS, layout string, pages ...string) (*Renderer, error) {
+	pageTemplates := make(map[string]*template.Template, len(pages))
+
+	for _, page := range pages {
+		name := strings.TrimSuffix(filepath.Base(page), filepath.Ext(page))
+
+		tmpl, err := template.New(filepath.Base(layout)).Funcs(templateFuncs).ParseFS(templateFS, layout, page)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template %q: %w", page, err)
+		}
+
+		pageTemplates[name] = tmpl
+	}
+
+	return &Renderer{pages: pageTemplates}, nil
+}
+
+// Render executes the named page template, writing a 500 on error.
+func (ren *Renderer) Render(w http.ResponseWriter, name string, data any) {
+	tmpl, ok := ren.pages[name]
+	if !ok {
+		http.Error(w, "Template Error: unknown page "+name, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Template Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// slugify turns arbitrary text into a URL-safe slug: lower-cased,
+// non-alphanumeric runs collapsed to a single hyphen, and leading/trailing
+// hyphens trimmed.
+func slugify(s string) string {
+	var b strings.Builder
+	lastHyphen := true // avoid a leading hyphen
+
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			lastHyphen = false
+		default:
+			if !lastHyphen {
+				b.WriteByte('-')
+				lastHyphen = true
+			}
+		}
+	}
+
+	return strings.Trim(b.String(), "-")
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// contentDirFlag, when set, makes loadPosts read Markdown from this
+// directory (and watch it with fsnotify) instead of the embedded contentFS.
+var contentDirFlag = flag.String("content-dir", "", "directory of Markdown content to read and watch instead of the embedded copy")
+
+// adminReloadToken gates POST /admin/reload. An empty token disables the
+// endpoint entirely, since that would otherwise mean "no auth required".
+var adminReloadToken = os.Getenv("ADMIN_RELOAD_TOKEN")
+
+// loadPostsFromDir reads every *.md file directly under dir, along with
+// the path-to-slug mapping needed to remove the right cache entry later if
+// a file whose frontmatter sets a custom Slug is deleted.
+func loadPostsFromDir(dir string) ([]Post, map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var posts []Post
+	paths := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		post, err := loadPostFromPath(path)
+		if err != nil {
+			log.Println("Error parsing", entry.Name(), ":", err)
+			continue
+		}
+		if post != nil {
+			posts = append(posts, *post)
+			paths[path] = post.Slug
+		}
+	}
+	return posts, paths, nil
+}
+
+func loadPostFromPath(path string) (*Post, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	slug := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return parsePostFile(slug, content)
+}
+
+// watchContent watches dir for create/write/rename/remove events and keeps
+// postCache in sync, debouncing bursts of events (editors routinely fire
+// several per save) before re-parsing the affected files.
+func watchContent(dir string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Println("fsnotify: could not start watcher:", err)
+		return
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		log.Println("fsnotify: could not watch", dir, ":", err)
+		return
+	}
+
+	const debounce = 200 * time.Millisecond
+
+	var (
+		mu      sync.Mutex
+		pending = make(map[string]struct{})
+		timer   *time.Timer
+	)
+
+	flush := func() {
+		mu.Lock()
+		paths := make([]string, 0, len(pending))
+		for p := range pending {
+			paths = append(paths, p)
+		}
+		pending = make(map[string]struct{})
+		mu.Unlock()
+
+		for _, p := range paths {
+			reloadPath(p)
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Ext(event.Name) != ".md" {
+				continue
+			}
+
+			mu.Lock()
+			pending[event.Name] = struct{}{}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(debounce, flush)
+			mu.Unlock()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Println("fsnotify error:", err)
+		}
+	}
+}
+
+// reloadPath re-parses a single changed file and applies the result to
+// postCache, or removes the corresponding post if the file is gone.
+//
+// The post's cached slug isn't always the filename: frontmatter can set an
+// explicit Slug. pathToSlug records what a given path last resolved to, so
+// removal targets the post that's actually in postCache/postLookup instead
+// of assuming filename == slug.
+func reloadPath(path string) {
+	if _, err := os.Stat(path); err != nil {
+		removePostByPath(path)
+		return
+	}
+
+	post, err := loadPostFromPath(path)
+	if err != nil {
+		log.Println("Error reloading", path, ":", err)
+		return
+	}
+	if post == nil { // filtered draft
+		removePostByPath(path)
+		return
+	}
+
+	upsertPost(*post)
+	setPathSlug(path, post.Slug)
+	log.Println("Reloaded post:", post.Slug)
+}
+
+// removePostByPath removes whatever post path last resolved to (falling
+// back to the filename-derived slug if path was never seen, e.g. it never
+// successfully parsed), and forgets the path->slug mapping.
+func removePostByPath(path string) {
+	slug := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	postCacheMu.Lock()
+	if s, ok := pathToSlug[path]; ok {
+		slug = s
+		delete(pathToSlug, path)
+	}
+	postCacheMu.Unlock()
+
+	removePost(slug)
+	log.Println("Removed post:", slug)
+}
+
+// setPathSlug records the slug a content path currently resolves to.
+func setPathSlug(path, slug string) {
+	postCacheMu.Lock()
+	if pathToSlug == nil {
+		pathToSlug = make(map[string]string)
+	}
+	pathToSlug[path] = slug
+	postCacheMu.Unlock()
+}
+
+// upsertPost replaces the post with a matching slug, or appends it, then
+// re-sorts by date. It builds a new slice rather than mutating postCache in
+// place so concurrent readers holding an old slice header never observe a
+// half-written element.
+func upsertPost(post Post) {
+	postCacheMu.Lock()
+	defer postCacheMu.Unlock()
+
+	next := make([]Post, 0, len(postCache)+1)
+	replaced := false
+	for _, p := range postCache {
+		if p.Slug == post.Slug {
+			next = append(next, post)
+			replaced = true
+			continue
+		}
+		next = append(next, p)
+	}
+	if !replaced {
+		next = append(next, post)
+	}
+
+	sort.Slice(next, func(i, j int) bool {
+		return next[i].Date.After(next[j].Date)
+	})
+	postCache = next
+
+	lookup := make(map[string]Post, len(postLookup)+1)
+	for k, v := range postLookup {
+		lookup[k] = v
+	}
+	lookup[post.Slug] = post
+	postLookup = lookup
+}
+
+func removePost(slug string) {
+	postCacheMu.Lock()
+	defer postCacheMu.Unlock()
+
+	lookup := make(map[string]Post, len(postLookup))
+	for k, v := range postLookup {
+		if k != slug {
+			lookup[k] = v
+		}
+	}
+	postLookup = lookup
+
+	next := make([]Post, 0, len(postCache))
+	for _, p := range postCache {
+		if p.Slug != slug {
+			next = append(next, p)
+		}
+	}
+	postCache = next
+}
+
+// adminReloadHandler forces a full content rescan, guarded by a shared
+// bearer token (ADMIN_RELOAD_TOKEN). Useful after bulk content edits that
+// fsnotify might miss (e.g. deploys that replace the whole directory).
+func adminReloadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if adminReloadToken == "" || r.Header.Get("Authorization") != "Bearer "+adminReloadToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rescanPosts()
+
+	postCacheMu.RLock()
+	n := len(postCache)
+	postCacheMu.RUnlock()
+
+	fmt.Fprintf(w, "reloaded %d posts\n", n)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func resetPostCache(t *testing.T, posts []Post) {
+	t.Helper()
+	setPostCache(posts)
+	t.Cleanup(func() { setPostCache(nil) })
+}
+
+func TestUpsertPostAddsAndReplaces(t *testing.T) {
+	now := time.Now()
+	resetPostCache(t, []Post{
+		{Slug: "a", Title: "A", Date: now.Add(-time.Hour)},
+	})
+
+	// New slug: appended.
+	upsertPost(Post{Slug: "b", Title: "B", Date: now})
+
+	postCacheMu.RLock()
+	n := len(postCache)
+	bPost, bOK := postLookup["b"]
+	postCacheMu.RUnlock()
+
+	if n != 2 {
+		t.Fatalf("len(postCache) = %d; want 2", n)
+	}
+	if !bOK || bPost.Title != "B" {
+		t.Fatalf("postLookup[\"b\"] = %+v, %v; want {Title: B}, true", bPost, bOK)
+	}
+
+	// Existing slug: replaced in place, not duplicated.
+	upsertPost(Post{Slug: "a", Title: "A updated", Date: now.Add(-time.Hour)})
+
+	postCacheMu.RLock()
+	n = len(postCache)
+	aPost := postLookup["a"]
+	postCacheMu.RUnlock()
+
+	if n != 2 {
+		t.Fatalf("len(postCache) after replace = %d; want 2", n)
+	}
+	if aPost.Title != "A updated" {
+		t.Errorf("postLookup[\"a\"].Title = %q; want %q", aPost.Title, "A updated")
+	}
+}
+
+func TestUpsertPostKeepsNewestFirst(t *testing.T) {
+	now := time.Now()
+	resetPostCache(t, []Post{
+		{Slug: "old", Date: now.Add(-24 * time.Hour)},
+	})
+
+	upsertPost(Post{Slug: "new", Date: now})
+
+	postCacheMu.RLock()
+	defer postCacheMu.RUnlock()
+	if len(postCache) != 2 || postCache[0].Slug != "new" {
+		t.Errorf("postCache = %v; want newest (\"new\") first", postCache)
+	}
+}
+
+func TestRemovePostDeletesFromCacheAndLookup(t *testing.T) {
+	resetPostCache(t, []Post{
+		{Slug: "a"},
+		{Slug: "b"},
+	})
+
+	removePost("a")
+
+	postCacheMu.RLock()
+	defer postCacheMu.RUnlock()
+
+	if _, ok := postLookup["a"]; ok {
+		t.Error("postLookup still contains removed slug \"a\"")
+	}
+	for _, p := range postCache {
+		if p.Slug == "a" {
+			t.Error("postCache still contains removed slug \"a\"")
+		}
+	}
+	if len(postCache) != 1 {
+		t.Errorf("len(postCache) = %d; want 1", len(postCache))
+	}
+}
+
+func TestReloadPathRemovesByFrontmatterSlugNotFilename(t *testing.T) {
+	resetPostCache(t, nil)
+	t.Cleanup(func() {
+		postCacheMu.Lock()
+		pathToSlug = nil
+		postCacheMu.Unlock()
+	})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "myfile.md")
+	content := "---\ntitle: Custom\ndate: 2024-01-01\nslug: custom-slug\n---\n\nBody.\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	reloadPath(path)
+
+	postCacheMu.RLock()
+	_, ok := postLookup["custom-slug"]
+	postCacheMu.RUnlock()
+	if !ok {
+		t.Fatalf("postLookup missing \"custom-slug\" after initial load")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	reloadPath(path)
+
+	postCacheMu.RLock()
+	defer postCacheMu.RUnlock()
+	if _, ok := postLookup["custom-slug"]; ok {
+		t.Error("postLookup still serves \"custom-slug\" after its file was deleted")
+	}
+	if len(postCache) != 0 {
+		t.Errorf("postCache = %v; want empty after delete", postCache)
+	}
+}